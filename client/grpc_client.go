@@ -0,0 +1,575 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "github.com/neurosimio/simulator-plugin-interface/proto/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// retryableCodes are the gRPC status codes callWithTimeout treats as
+// transient connectivity problems worth resetting the connection and
+// retrying. Every other code - including application-level errors like
+// InvalidArgument or NotFound - is returned to the caller immediately
+// instead of tearing down a perfectly healthy connection and resending a
+// request that failed for a reason a retry can't fix.
+var retryableCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+}
+
+// isRetryableStatus reports whether err's gRPC status code is one
+// callWithTimeout should retry.
+func isRetryableStatus(err error) bool {
+	return retryableCodes[status.Code(err)]
+}
+
+// RetryPolicy controls how PluginServiceClient retries a failed RPC before
+// giving up, and how long any single attempt is allowed to run.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per call, including the
+	// first. A value of 1 disables retries; a value <= 0 makes
+	// orDefault replace the whole policy with DefaultRetryPolicy.
+	MaxAttempts int
+
+	// BaseDelay is the backoff used after the first failed attempt; each
+	// subsequent delay doubles, capped at MaxDelay, with up to ±50%
+	// jitter applied to avoid synchronized retry storms across clients.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// CallTimeout bounds each individual attempt of a unary RPC. The zero
+	// value leaves the caller's context deadline untouched.
+	CallTimeout time.Duration
+
+	// StreamMinTimeout is the minimum per-attempt timeout applied to a
+	// streaming RPC (EncodeMessageStream, DecodeMessageStream), regardless
+	// of payload size. Unlike CallTimeout, it is not a fixed timeout: it's a
+	// floor under the size-based timeout computed from StreamThroughput, and
+	// is used as the timeout only when the size-based value is smaller.
+	// The zero value uses DefaultRetryPolicy's 30s floor.
+	StreamMinTimeout time.Duration
+
+	// StreamThroughput is the assumed minimum sustained transfer rate, in
+	// bytes per second, used to size a streaming RPC's per-attempt timeout
+	// from its payload length so large transfers aren't capped at
+	// StreamMinTimeout. The zero value assumes 1MiB/s.
+	StreamThroughput int64
+}
+
+// DefaultRetryPolicy returns the retry behavior used when a
+// PluginClientConfig doesn't specify one.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:      3,
+		BaseDelay:        100 * time.Millisecond,
+		MaxDelay:         2 * time.Second,
+		CallTimeout:      10 * time.Second,
+		StreamMinTimeout: 30 * time.Second,
+		StreamThroughput: 1 << 20, // 1MiB/s
+	}
+}
+
+func (p RetryPolicy) orDefault() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		return DefaultRetryPolicy()
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry attempt (1-indexed:
+// attempt 1 is the delay after the first failure), with jitter applied.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay * time.Duration(1<<uint(attempt-1))
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	jitter := time.Duration(rand.Float64() * float64(delay))
+	return delay/2 + jitter
+}
+
+// streamTimeout returns the per-attempt timeout for a streaming RPC
+// transferring dataLen bytes: StreamThroughput (or its 1MiB/s default)
+// applied to dataLen, floored at StreamMinTimeout (or its 30s default).
+// Unlike CallTimeout, it scales with payload size so a bulk transfer isn't
+// capped at a timeout sized for a small unary RPC.
+func (p RetryPolicy) streamTimeout(dataLen int) time.Duration {
+	minTimeout := p.StreamMinTimeout
+	if minTimeout <= 0 {
+		minTimeout = 30 * time.Second
+	}
+	throughput := p.StreamThroughput
+	if throughput <= 0 {
+		throughput = 1 << 20
+	}
+
+	sizeBased := time.Duration(float64(dataLen) / float64(throughput) * float64(time.Second))
+	if sizeBased > minTimeout {
+		return sizeBased
+	}
+	return minTimeout
+}
+
+// PluginClientConfig configures how NewPluginServiceClient dials a peer
+// plugin's gRPC endpoint.
+type PluginClientConfig struct {
+	// TLS configures mTLS for the connection. A nil TLS falls back to
+	// plaintext gRPC, which is only appropriate for localhost plugins.
+	TLS *TLSConfig
+
+	// DialOptions are appended after the transport credentials option,
+	// letting callers add interceptors, keepalive parameters, etc.
+	// without NewPluginServiceClient needing to know about them.
+	DialOptions []grpc.DialOption
+
+	// Retry controls the retry/backoff behavior applied to every RPC.
+	// The zero value falls back to DefaultRetryPolicy.
+	Retry RetryPolicy
+
+	// HealthProbeInterval, when non-zero, causes StartHealthProbe to poll
+	// the plugin's HealthCheck RPC on this interval in the background.
+	HealthProbeInterval time.Duration
+
+	// UnhealthyThreshold is the number of consecutive failed health
+	// probes before the connection is torn down and reconnected on the
+	// next RPC. Defaults to 3.
+	UnhealthyThreshold int
+
+	// MaxRecvMsgSize and MaxSendMsgSize override gRPC's default 4MB
+	// message size limit. Zero leaves the gRPC default in place; set
+	// these when a plugin exchanges bulk telemetry frames that exceed it.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	// RetryNonIdempotent, when true, allows a failed RPC that isn't
+	// idempotent (currently just ProcessMessage) to be retried on a
+	// transient error, not just on a failure to (re)establish the
+	// connection. The default (false) is safer: since a transient error
+	// like DeadlineExceeded can mean the request was processed but its
+	// response was lost, blindly retrying risks running a non-idempotent
+	// RPC twice. Set this only if the plugin's ProcessMessage handler is
+	// itself idempotent (e.g. keyed by a message ID it deduplicates on).
+	RetryNonIdempotent bool
+
+	// Resolver, when set, discovers the peer plugin's address dynamically
+	// instead of dialing the literal endpoint passed to
+	// NewPluginServiceClient, so a plugin can be dialed by logical name
+	// (e.g. via Consul or DNS SRV) rather than a hard-coded host:port.
+	// It is re-resolved every time the connection is (re)established, so
+	// a reconnect after an address change picks up the new location.
+	Resolver Resolver
+
+	// UnaryInterceptors and StreamInterceptors are installed as a chain,
+	// in order, ahead of any interceptors in DialOptions. Use
+	// DefaultUnaryInterceptors/DefaultStreamInterceptors to get the
+	// standard logging, metrics, recovery, and tracing behavior, then
+	// append application-specific interceptors to the returned slice.
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+}
+
+// PluginServiceClient is a gRPC client for connecting to other plugins. The
+// underlying connection is established lazily on the first RPC and is
+// transparently recreated after connectivity loss, so callers can hold a
+// PluginServiceClient for the lifetime of the process without worrying
+// about a plugin that is slow to start or briefly unreachable.
+type PluginServiceClient struct {
+	endpoint string
+	config   *PluginClientConfig
+	retry    RetryPolicy
+
+	mu     sync.Mutex
+	conn   *grpc.ClientConn
+	client pb.PluginServiceClient
+
+	probeCancel context.CancelFunc
+}
+
+// NewPluginServiceClient creates a new gRPC client for plugin communication.
+// It does not dial the endpoint; the connection is created on first use by
+// ensureConn. A nil config dials insecurely, preserving the previous
+// default for localhost development.
+func NewPluginServiceClient(endpoint string, config *PluginClientConfig) (*PluginServiceClient, error) {
+	if config == nil {
+		config = &PluginClientConfig{TLS: &TLSConfig{Insecure: true}}
+	}
+
+	return &PluginServiceClient{
+		endpoint: endpoint,
+		config:   config,
+		retry:    config.Retry.orDefault(),
+	}, nil
+}
+
+// resolveEndpoint returns the address to dial: the literal endpoint given
+// to NewPluginServiceClient, or the first address returned by
+// config.Resolver if one is set. ctx bounds the resolver lookup itself, so
+// a hung DNS/Consul/Kubernetes query can't block the caller forever.
+func (c *PluginServiceClient) resolveEndpoint(ctx context.Context) (string, error) {
+	if c.config.Resolver == nil {
+		return c.endpoint, nil
+	}
+
+	endpoints, err := c.config.Resolver.Resolve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve endpoint %s: %w", c.endpoint, err)
+	}
+	if len(endpoints) == 0 {
+		return "", fmt.Errorf("resolver returned no endpoints for %s", c.endpoint)
+	}
+
+	return endpoints[0].Address(), nil
+}
+
+// ensureConn returns the current connection, dialing it if this is the
+// first call or if a previous failure reset it to nil. ctx bounds endpoint
+// resolution only; it has no effect once a connection exists. Callers must
+// hold c.mu.
+func (c *PluginServiceClient) ensureConn(ctx context.Context) (pb.PluginServiceClient, error) {
+	if c.conn != nil {
+		return c.client, nil
+	}
+
+	addr, err := c.resolveEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := transportCredentials(c.config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS for %s: %w", c.endpoint, err)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	var callOpts []grpc.CallOption
+	if c.config.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(c.config.MaxRecvMsgSize))
+	}
+	if c.config.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(c.config.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if len(c.config.UnaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(c.config.UnaryInterceptors...))
+	}
+	if len(c.config.StreamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(c.config.StreamInterceptors...))
+	}
+
+	opts = append(opts, c.config.DialOptions...)
+
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to plugin at %s: %w", addr, err)
+	}
+
+	c.conn = conn
+	c.client = pb.NewPluginServiceClient(conn)
+	return c.client, nil
+}
+
+// resetConn closes and discards the current connection, forcing the next
+// RPC to redial. Callers must hold c.mu.
+func (c *PluginServiceClient) resetConn() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+		c.client = nil
+	}
+}
+
+// call runs fn against the client, reconnecting and retrying according to
+// c.retry on failure. Each attempt gets its own timeout derived from
+// c.retry.CallTimeout (if set) layered on top of ctx. idempotent must be
+// false for RPCs that have server-side side effects and aren't safe to
+// run twice (see callWithTimeout).
+func call[T any](ctx context.Context, c *PluginServiceClient, idempotent bool, fn func(context.Context, pb.PluginServiceClient) (T, error)) (T, error) {
+	return callWithTimeout(ctx, c, c.retry.CallTimeout, idempotent, fn)
+}
+
+// callStream is the streaming counterpart of call, used by
+// EncodeMessageStream/DecodeMessageStream. It sizes each attempt's timeout
+// from dataLen via c.retry.streamTimeout instead of reusing CallTimeout,
+// since a multi-chunk transfer can legitimately take far longer than a
+// single unary RPC.
+func callStream[T any](ctx context.Context, c *PluginServiceClient, dataLen int, idempotent bool, fn func(context.Context, pb.PluginServiceClient) (T, error)) (T, error) {
+	return callWithTimeout(ctx, c, c.retry.streamTimeout(dataLen), idempotent, fn)
+}
+
+// callWithTimeout is the shared implementation behind call and
+// callStream: it reconnects and retries according to c.retry, bounding
+// each attempt with timeout (if positive) layered on top of ctx.
+//
+// A failure to (re)establish the connection is always retried, since the
+// request was never sent. Once a request has been sent, a failure is only
+// retried if both: (1) its gRPC status code is one of retryableCodes, so a
+// permanent application error like InvalidArgument fails fast instead of
+// retrying something a retry can't fix, and (2) the RPC is either
+// idempotent or c.config.RetryNonIdempotent opts in, since retrying a
+// non-idempotent call risks running it twice if the first attempt actually
+// succeeded server-side but its response was lost.
+func callWithTimeout[T any](ctx context.Context, c *PluginServiceClient, timeout time.Duration, idempotent bool, fn func(context.Context, pb.PluginServiceClient) (T, error)) (T, error) {
+	var zero T
+	var lastErr error
+
+	retryCallErrors := idempotent || c.config.RetryNonIdempotent
+
+	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
+		c.mu.Lock()
+		client, err := c.ensureConn(ctx)
+		c.mu.Unlock()
+		if err != nil {
+			lastErr = err
+		} else {
+			attemptCtx := ctx
+			var cancel context.CancelFunc
+			if timeout > 0 {
+				attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			result, callErr := fn(attemptCtx, client)
+			if cancel != nil {
+				cancel()
+			}
+			if callErr == nil {
+				return result, nil
+			}
+			lastErr = callErr
+
+			if !retryCallErrors || !isRetryableStatus(callErr) {
+				return zero, callErr
+			}
+
+			c.mu.Lock()
+			c.resetConn()
+			c.mu.Unlock()
+		}
+
+		if attempt < c.retry.MaxAttempts {
+			select {
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			case <-time.After(c.retry.backoff(attempt)):
+			}
+		}
+	}
+
+	return zero, fmt.Errorf("all %d attempts failed, last error: %w", c.retry.MaxAttempts, lastErr)
+}
+
+// StartHealthProbe polls the plugin's HealthCheck RPC on
+// config.HealthProbeInterval in the background. After UnhealthyThreshold
+// consecutive failures, the connection is torn down so the next RPC
+// reconnects from scratch. The probe stops when ctx is done or Close is
+// called.
+func (c *PluginServiceClient) StartHealthProbe(ctx context.Context, service string) {
+	interval := c.config.HealthProbeInterval
+	if interval <= 0 {
+		return
+	}
+	threshold := c.config.UnhealthyThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	c.mu.Lock()
+	c.probeCancel = cancel
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var consecutiveFailures int
+		for {
+			select {
+			case <-probeCtx.Done():
+				return
+			case <-ticker.C:
+				if _, err := c.HealthCheck(probeCtx, service); err != nil {
+					consecutiveFailures++
+					if consecutiveFailures >= threshold {
+						c.mu.Lock()
+						c.resetConn()
+						c.mu.Unlock()
+						consecutiveFailures = 0
+					}
+				} else {
+					consecutiveFailures = 0
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the health probe (if running) and closes the gRPC
+// connection.
+func (c *PluginServiceClient) Close() error {
+	c.mu.Lock()
+	if c.probeCancel != nil {
+		c.probeCancel()
+	}
+	conn := c.conn
+	c.conn = nil
+	c.client = nil
+	c.mu.Unlock()
+
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// HealthCheck performs a health check on the connected plugin
+func (c *PluginServiceClient) HealthCheck(ctx context.Context, service string) (*pb.HealthCheckResponse, error) {
+	return call(ctx, c, true, func(ctx context.Context, client pb.PluginServiceClient) (*pb.HealthCheckResponse, error) {
+		return client.HealthCheck(ctx, &pb.HealthCheckRequest{Service: service})
+	})
+}
+
+// GetManifest retrieves the plugin's manifest
+func (c *PluginServiceClient) GetManifest(ctx context.Context) (*pb.PluginManifest, error) {
+	resp, err := call(ctx, c, true, func(ctx context.Context, client pb.PluginServiceClient) (*pb.GetManifestResponse, error) {
+		return client.GetManifest(ctx, &pb.GetManifestRequest{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Manifest, nil
+}
+
+// ProcessMessage sends a message to a component plugin for processing. It
+// is not retried on a failed RPC (only on a failure to reconnect), since
+// the plugin may have already applied the message's side effects before a
+// transient error like DeadlineExceeded lost the response; set
+// PluginClientConfig.RetryNonIdempotent if the plugin's handler is itself
+// idempotent and safe to call twice.
+func (c *PluginServiceClient) ProcessMessage(ctx context.Context, instanceID string, message *pb.SimMessage) (*pb.ProcessMessageResponse, error) {
+	return call(ctx, c, false, func(ctx context.Context, client pb.PluginServiceClient) (*pb.ProcessMessageResponse, error) {
+		return client.ProcessMessage(ctx, &pb.ProcessMessageRequest{
+			InstanceId: instanceID,
+			Message:    message,
+		})
+	})
+}
+
+// EncodeMessage encodes a message using a message ICD plugin
+func (c *PluginServiceClient) EncodeMessage(ctx context.Context, messageType, format string, payload map[string]interface{}) (*pb.EncodeMessageResponse, error) {
+	payloadStruct, err := structFromPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert payload: %w", err)
+	}
+
+	return call(ctx, c, true, func(ctx context.Context, client pb.PluginServiceClient) (*pb.EncodeMessageResponse, error) {
+		req := &pb.EncodeMessageRequest{
+			MessageType: messageType,
+			Format:      format,
+			Payload:     payloadStruct,
+		}
+		return client.EncodeMessage(ctx, req)
+	})
+}
+
+// defaultStreamChunkSize keeps individual stream frames comfortably under
+// the default 4MB gRPC message limit even with protobuf framing overhead.
+const defaultStreamChunkSize = 1 << 20 // 1MiB
+
+// EncodeMessageStream encodes a message whose serialized payload may
+// exceed the default gRPC message size limit, streaming it to the plugin
+// in chunkSize chunks instead of a single EncodeMessage call. A chunkSize
+// <= 0 uses defaultStreamChunkSize.
+func (c *PluginServiceClient) EncodeMessageStream(ctx context.Context, messageType, format string, payload map[string]interface{}, chunkSize int) (*pb.EncodeMessageResponse, error) {
+	payloadStruct, err := structFromPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert payload: %w", err)
+	}
+	data, err := proto.Marshal(payloadStruct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if chunkSize <= 0 {
+		chunkSize = defaultStreamChunkSize
+	}
+
+	return callStream(ctx, c, len(data), true, func(ctx context.Context, client pb.PluginServiceClient) (*pb.EncodeMessageResponse, error) {
+		stream, err := client.EncodeMessageStream(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open encode stream: %w", err)
+		}
+
+		for offset := 0; offset < len(data); offset += chunkSize {
+			end := offset + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+			chunk := &pb.EncodeMessageStreamRequest{
+				MessageType: messageType,
+				Format:      format,
+				Chunk:       data[offset:end],
+			}
+			if err := stream.Send(chunk); err != nil {
+				return nil, fmt.Errorf("failed to send payload chunk: %w", err)
+			}
+		}
+
+		return stream.CloseAndRecv()
+	})
+}
+
+// DecodeMessageStream decodes a message whose raw encoded payload may
+// exceed the default gRPC message size limit, streaming encodedPayload to
+// the plugin in chunkSize chunks instead of a single DecodeMessage call.
+// A chunkSize <= 0 uses defaultStreamChunkSize.
+func (c *PluginServiceClient) DecodeMessageStream(ctx context.Context, messageType, contentType string, encodedPayload []byte, chunkSize int) (*pb.DecodeMessageResponse, error) {
+	if chunkSize <= 0 {
+		chunkSize = defaultStreamChunkSize
+	}
+
+	return callStream(ctx, c, len(encodedPayload), true, func(ctx context.Context, client pb.PluginServiceClient) (*pb.DecodeMessageResponse, error) {
+		stream, err := client.DecodeMessageStream(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open decode stream: %w", err)
+		}
+
+		for offset := 0; offset < len(encodedPayload); offset += chunkSize {
+			end := offset + chunkSize
+			if end > len(encodedPayload) {
+				end = len(encodedPayload)
+			}
+			chunk := &pb.DecodeMessageStreamRequest{
+				MessageType: messageType,
+				ContentType: contentType,
+				Chunk:       encodedPayload[offset:end],
+			}
+			if err := stream.Send(chunk); err != nil {
+				return nil, fmt.Errorf("failed to send encoded payload chunk: %w", err)
+			}
+		}
+
+		return stream.CloseAndRecv()
+	})
+}
+
+// DecodeMessage decodes a message using a message ICD plugin
+func (c *PluginServiceClient) DecodeMessage(ctx context.Context, messageType, contentType string, encodedPayload []byte) (*pb.DecodeMessageResponse, error) {
+	return call(ctx, c, true, func(ctx context.Context, client pb.PluginServiceClient) (*pb.DecodeMessageResponse, error) {
+		return client.DecodeMessage(ctx, &pb.DecodeMessageRequest{
+			MessageType:    messageType,
+			ContentType:    contentType,
+			EncodedPayload: encodedPayload,
+		})
+	})
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// LoggingUnaryClientInterceptor logs each unary RPC's method, latency, and
+// request/response size via slog. A nil logger uses slog.Default().
+func LoggingUnaryClientInterceptor(logger *slog.Logger) grpc.UnaryClientInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		attrs := []any{"method", method, "duration", time.Since(start)}
+		if msg, ok := req.(proto.Message); ok {
+			attrs = append(attrs, "requestBytes", proto.Size(msg))
+		}
+		if msg, ok := reply.(proto.Message); ok {
+			attrs = append(attrs, "responseBytes", proto.Size(msg))
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "grpc call failed", append(attrs, "error", err)...)
+		} else {
+			logger.InfoContext(ctx, "grpc call completed", attrs...)
+		}
+		return err
+	}
+}
+
+// LoggingStreamClientInterceptor logs a streaming RPC's method and the
+// latency to open the stream via slog. A nil logger uses slog.Default().
+func LoggingStreamClientInterceptor(logger *slog.Logger) grpc.StreamClientInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logger.ErrorContext(ctx, "grpc stream failed to open", "method", method, "duration", time.Since(start), "error", err)
+		} else {
+			logger.InfoContext(ctx, "grpc stream opened", "method", method, "duration", time.Since(start))
+		}
+		return stream, err
+	}
+}
+
+// RecoveryUnaryClientInterceptor converts a panic raised while invoking a
+// unary RPC into a codes.Internal error instead of crashing the process,
+// logging the stack trace for diagnosis. A nil logger uses slog.Default().
+func RecoveryUnaryClientInterceptor(logger *slog.Logger) grpc.UnaryClientInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "recovered panic in grpc call", "method", method, "panic", r, "stack", string(debug.Stack()))
+				err = status.Errorf(codes.Internal, "panic during %s: %v", method, r)
+			}
+		}()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// RecoveryStreamClientInterceptor is the streaming counterpart of
+// RecoveryUnaryClientInterceptor, guarding the call that opens the stream.
+func RecoveryStreamClientInterceptor(logger *slog.Logger) grpc.StreamClientInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (stream grpc.ClientStream, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "recovered panic opening grpc stream", "method", method, "panic", r, "stack", string(debug.Stack()))
+				stream, err = nil, status.Errorf(codes.Internal, "panic opening stream %s: %v", method, r)
+			}
+		}()
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// ClientMetrics holds the Prometheus collectors used by the default
+// metrics interceptors: RPC counts, latency histograms, and an in-flight
+// gauge, labeled by method.
+type ClientMetrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        *prometheus.GaugeVec
+}
+
+// NewClientMetrics creates and registers the collectors backing
+// ClientMetrics on registry. A nil registry registers against
+// prometheus.DefaultRegisterer.
+func NewClientMetrics(registry prometheus.Registerer) *ClientMetrics {
+	if registry == nil {
+		registry = prometheus.DefaultRegisterer
+	}
+	factory := promauto.With(registry)
+
+	return &ClientMetrics{
+		requestsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "plugin_client_grpc_requests_total",
+			Help: "Total number of gRPC requests made to plugins, labeled by method and outcome.",
+		}, []string{"method", "code"}),
+		requestDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "plugin_client_grpc_request_duration_seconds",
+			Help:    "Latency of gRPC requests made to plugins, labeled by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		inFlight: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "plugin_client_grpc_requests_in_flight",
+			Help: "Number of gRPC requests to plugins currently in flight, labeled by method.",
+		}, []string{"method"}),
+	}
+}
+
+// UnaryClientInterceptor records request counts, latency, and in-flight
+// gauges for unary RPCs.
+func (m *ClientMetrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		m.inFlight.WithLabelValues(method).Inc()
+		defer m.inFlight.WithLabelValues(method).Dec()
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		m.requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		m.requestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+		return err
+	}
+}
+
+// StreamClientInterceptor records request counts, latency, and in-flight
+// gauges for the call that opens a streaming RPC.
+func (m *ClientMetrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		m.inFlight.WithLabelValues(method).Inc()
+		defer m.inFlight.WithLabelValues(method).Dec()
+
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+
+		m.requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		m.requestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+		return stream, err
+	}
+}
+
+// ServeMetrics serves the collectors registered on gatherer at addr's
+// /metrics endpoint until ctx is canceled. Run it in its own goroutine. A
+// nil gatherer serves prometheus.DefaultGatherer, matching the registry
+// NewClientMetrics defaults to when given a nil Registerer; pass the same
+// registry used with NewClientMetrics here so /metrics actually reflects
+// the collectors that were registered.
+func ServeMetrics(ctx context.Context, addr string, gatherer prometheus.Gatherer) error {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// TracingUnaryClientInterceptor propagates the active OpenTelemetry trace
+// context across the gRPC call and records the RPC as a client span.
+func TracingUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return otelgrpc.UnaryClientInterceptor()
+}
+
+// TracingStreamClientInterceptor is the streaming counterpart of
+// TracingUnaryClientInterceptor.
+func TracingStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return otelgrpc.StreamClientInterceptor()
+}
+
+// DefaultUnaryInterceptors returns the standard interceptor chain - panic
+// recovery, structured logging, trace propagation, and (if metrics is
+// non-nil) Prometheus metrics - in the order they should be installed.
+// Callers can append their own interceptors after this slice without
+// losing the defaults.
+func DefaultUnaryInterceptors(logger *slog.Logger, metrics *ClientMetrics) []grpc.UnaryClientInterceptor {
+	interceptors := []grpc.UnaryClientInterceptor{
+		RecoveryUnaryClientInterceptor(logger),
+		LoggingUnaryClientInterceptor(logger),
+		TracingUnaryClientInterceptor(),
+	}
+	if metrics != nil {
+		interceptors = append(interceptors, metrics.UnaryClientInterceptor())
+	}
+	return interceptors
+}
+
+// DefaultStreamInterceptors is the streaming counterpart of
+// DefaultUnaryInterceptors.
+func DefaultStreamInterceptors(logger *slog.Logger, metrics *ClientMetrics) []grpc.StreamClientInterceptor {
+	interceptors := []grpc.StreamClientInterceptor{
+		RecoveryStreamClientInterceptor(logger),
+		LoggingStreamClientInterceptor(logger),
+		TracingStreamClientInterceptor(),
+	}
+	if metrics != nil {
+		interceptors = append(interceptors, metrics.StreamClientInterceptor())
+	}
+	return interceptors
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// PayloadConversionError is returned when a value in an EncodeMessage
+// payload has no protobuf representation (e.g. a channel or function).
+// It identifies the offending field so callers can report a useful error
+// back to whatever produced the payload.
+type PayloadConversionError struct {
+	Path  string
+	Value interface{}
+}
+
+func (e *PayloadConversionError) Error() string {
+	return fmt.Sprintf("payload field %q has type %T, which cannot be converted to a protobuf value", e.Path, e.Value)
+}
+
+// structFromPayload recursively converts a plain Go map - as produced by
+// unmarshaling arbitrary JSON, or assembled by hand - into a
+// *structpb.Struct suitable for EncodeMessageRequest.Payload.
+func structFromPayload(payload map[string]interface{}) (*structpb.Struct, error) {
+	fields, err := fieldsFromMap(payload, "")
+	if err != nil {
+		return nil, err
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+func fieldsFromMap(m map[string]interface{}, path string) (map[string]*structpb.Value, error) {
+	fields := make(map[string]*structpb.Value, len(m))
+	for key, v := range m {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		value, err := valueFromAny(v, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+	}
+	return fields, nil
+}
+
+func valueFromAny(v interface{}, path string) (*structpb.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case bool:
+		return structpb.NewBoolValue(val), nil
+	case string:
+		return structpb.NewStringValue(val), nil
+	case float32:
+		return structpb.NewNumberValue(float64(val)), nil
+	case float64:
+		return structpb.NewNumberValue(val), nil
+	case int:
+		return structpb.NewNumberValue(float64(val)), nil
+	case int32:
+		return structpb.NewNumberValue(float64(val)), nil
+	case int64:
+		return structpb.NewNumberValue(float64(val)), nil
+	case map[string]interface{}:
+		fields, err := fieldsFromMap(val, path)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+	case []interface{}:
+		values := make([]*structpb.Value, len(val))
+		for i, item := range val {
+			value, err := valueFromAny(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	default:
+		return nil, &PayloadConversionError{Path: path, Value: v}
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStructFromPayload_ConvertsScalarsAndNesting(t *testing.T) {
+	payload := map[string]interface{}{
+		"name":    "sensor-1",
+		"count":   int(3),
+		"ratio":   1.5,
+		"enabled": true,
+		"tags":    []interface{}{"a", "b"},
+		"nested": map[string]interface{}{
+			"inner": int64(42),
+		},
+		"empty": nil,
+	}
+
+	got, err := structFromPayload(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fields := got.GetFields()
+	if fields["name"].GetStringValue() != "sensor-1" {
+		t.Errorf("name = %v, want sensor-1", fields["name"])
+	}
+	if fields["count"].GetNumberValue() != 3 {
+		t.Errorf("count = %v, want 3", fields["count"])
+	}
+	if fields["ratio"].GetNumberValue() != 1.5 {
+		t.Errorf("ratio = %v, want 1.5", fields["ratio"])
+	}
+	if !fields["enabled"].GetBoolValue() {
+		t.Errorf("enabled = %v, want true", fields["enabled"])
+	}
+	if _, isNull := fields["empty"].GetKind().(*structpb.Value_NullValue); !isNull {
+		t.Errorf("empty = %v, want null", fields["empty"])
+	}
+
+	tags := fields["tags"].GetListValue().GetValues()
+	if len(tags) != 2 || tags[0].GetStringValue() != "a" || tags[1].GetStringValue() != "b" {
+		t.Errorf("tags = %v, want [a b]", tags)
+	}
+
+	inner := fields["nested"].GetStructValue().GetFields()["inner"]
+	if inner.GetNumberValue() != 42 {
+		t.Errorf("nested.inner = %v, want 42", inner)
+	}
+}
+
+func TestStructFromPayload_UnsupportedTypeReturnsPathedError(t *testing.T) {
+	payload := map[string]interface{}{
+		"callback": func() {},
+	}
+
+	_, err := structFromPayload(payload)
+	if err == nil {
+		t.Fatal("expected an error for an unsupported value type")
+	}
+
+	var convErr *PayloadConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("expected a *PayloadConversionError, got %T: %v", err, err)
+	}
+	if convErr.Path != "callback" {
+		t.Errorf("Path = %q, want %q", convErr.Path, "callback")
+	}
+}
+
+func TestStructFromPayload_UnsupportedTypeInNestedCollectionsReportsFullPath(t *testing.T) {
+	payload := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{
+				"bad": make(chan int),
+			},
+		},
+	}
+
+	_, err := structFromPayload(payload)
+	if err == nil {
+		t.Fatal("expected an error for an unsupported nested value type")
+	}
+
+	var convErr *PayloadConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("expected a *PayloadConversionError, got %T: %v", err, err)
+	}
+	if want := "items[0].bad"; convErr.Path != want {
+		t.Errorf("Path = %q, want %q", convErr.Path, want)
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PluginClientPool maintains one PluginServiceClient per plugin ID,
+// reusing a single gRPC connection across concurrent callers instead of
+// dialing a new connection for every RPC fan-out. Pooled clients already
+// reconnect on socket loss (see PluginServiceClient), so the pool only
+// needs to worry about first-time creation and graceful shutdown.
+type PluginClientPool struct {
+	// dialConfig is used for every client the pool creates. TLS and retry
+	// settings are shared across all pooled plugins.
+	dialConfig *PluginClientConfig
+
+	clients sync.Map // pluginID (string) -> *PluginServiceClient
+}
+
+// NewPluginClientPool creates a pool that dials pooled plugins using
+// dialConfig. A nil dialConfig dials insecurely, matching
+// NewPluginServiceClient's default.
+func NewPluginClientPool(dialConfig *PluginClientConfig) *PluginClientPool {
+	return &PluginClientPool{dialConfig: dialConfig}
+}
+
+// Register creates (or replaces) the pooled client for pluginID, dialing
+// grpcAddress. Replacing an existing entry closes the old client first so
+// connections are never leaked.
+//
+// grpcAddress is the concrete, already-resolved endpoint for this one
+// plugin, so Register dials it directly rather than through
+// dialConfig.Resolver: dialConfig is shared by every plugin in the pool,
+// and resolving through it here would send every pooled client to
+// whatever address that single shared Resolver happens to return instead
+// of the distinct address each plugin registered with.
+func (p *PluginClientPool) Register(pluginID, grpcAddress string) error {
+	config := p.dialConfig
+	if config != nil && config.Resolver != nil {
+		configCopy := *config
+		configCopy.Resolver = nil
+		config = &configCopy
+	}
+
+	client, err := NewPluginServiceClient(grpcAddress, config)
+	if err != nil {
+		return fmt.Errorf("failed to create pooled client for plugin %s: %w", pluginID, err)
+	}
+
+	if old, loaded := p.clients.Swap(pluginID, client); loaded {
+		old.(*PluginServiceClient).Close()
+	}
+
+	return nil
+}
+
+// Get returns the pooled client for pluginID, or an error if no plugin
+// with that ID has been registered.
+func (p *PluginClientPool) Get(pluginID string) (*PluginServiceClient, error) {
+	value, ok := p.clients.Load(pluginID)
+	if !ok {
+		return nil, fmt.Errorf("no pooled client for plugin %s", pluginID)
+	}
+	return value.(*PluginServiceClient), nil
+}
+
+// Close closes every pooled client, returning the first error encountered
+// (if any) after attempting to close them all.
+func (p *PluginClientPool) Close() error {
+	var firstErr error
+	p.clients.Range(func(key, value any) bool {
+		if err := value.(*PluginServiceClient).Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close client for plugin %v: %w", key, err)
+		}
+		p.clients.Delete(key)
+		return true
+	})
+	return firstErr
+}
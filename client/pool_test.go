@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+// stubResolver is a Resolver that always resolves to the same fixed
+// endpoint, used to stand in for a real Consul/DNS lookup in tests.
+type stubResolver struct {
+	endpoint Endpoint
+}
+
+func (r stubResolver) Resolve(ctx context.Context) ([]Endpoint, error) {
+	return []Endpoint{r.endpoint}, nil
+}
+
+func TestPluginClientPool_RegisterUsesOwnAddressNotSharedResolver(t *testing.T) {
+	shared := stubResolver{endpoint: Endpoint{Host: "shared-resolved-host", Port: "9999"}}
+	pool := NewPluginClientPool(&PluginClientConfig{
+		TLS:      &TLSConfig{Insecure: true},
+		Resolver: shared,
+	})
+
+	if err := pool.Register("plugin-a", "plugin-a.local:7000"); err != nil {
+		t.Fatalf("Register(plugin-a): %v", err)
+	}
+	if err := pool.Register("plugin-b", "plugin-b.local:7001"); err != nil {
+		t.Fatalf("Register(plugin-b): %v", err)
+	}
+
+	clientA, err := pool.Get("plugin-a")
+	if err != nil {
+		t.Fatalf("Get(plugin-a): %v", err)
+	}
+	clientB, err := pool.Get("plugin-b")
+	if err != nil {
+		t.Fatalf("Get(plugin-b): %v", err)
+	}
+
+	if clientA.endpoint != "plugin-a.local:7000" {
+		t.Errorf("plugin-a endpoint = %q, want its own registered address", clientA.endpoint)
+	}
+	if clientB.endpoint != "plugin-b.local:7001" {
+		t.Errorf("plugin-b endpoint = %q, want its own registered address", clientB.endpoint)
+	}
+	if clientA.config.Resolver != nil || clientB.config.Resolver != nil {
+		t.Error("pooled clients should have the shared Resolver cleared so resolveEndpoint uses their own literal address")
+	}
+
+	// The pool's own dialConfig must be left untouched for the next caller.
+	if pool.dialConfig.Resolver == nil {
+		t.Error("pool.dialConfig.Resolver was mutated; Register must copy before clearing it")
+	}
+}
+
+func TestPluginClientPool_RegisterWithoutResolverKeepsConfig(t *testing.T) {
+	pool := NewPluginClientPool(&PluginClientConfig{TLS: &TLSConfig{Insecure: true}})
+
+	if err := pool.Register("plugin-a", "plugin-a.local:7000"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	client, err := pool.Get("plugin-a")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if client.config != pool.dialConfig {
+		t.Error("with no Resolver set, Register should reuse dialConfig directly rather than copying it")
+	}
+}
+
+func TestPluginClientPool_GetUnknownPluginErrors(t *testing.T) {
+	pool := NewPluginClientPool(nil)
+	if _, err := pool.Get("nope"); err == nil {
+		t.Fatal("expected an error for an unregistered plugin ID")
+	}
+}
+
+func TestPluginClientPool_RegisterReplacesAndClosesOldClient(t *testing.T) {
+	pool := NewPluginClientPool(nil)
+
+	if err := pool.Register("plugin-a", "first.local:7000"); err != nil {
+		t.Fatalf("first Register: %v", err)
+	}
+	first, _ := pool.Get("plugin-a")
+
+	// Give the first client a connection so that closing it is observable.
+	first.mu.Lock()
+	_, err := first.ensureConn(context.Background())
+	first.mu.Unlock()
+	if err != nil {
+		t.Fatalf("ensureConn on first client: %v", err)
+	}
+
+	if err := pool.Register("plugin-a", "second.local:7000"); err != nil {
+		t.Fatalf("second Register: %v", err)
+	}
+	second, _ := pool.Get("plugin-a")
+
+	first.mu.Lock()
+	firstClosed := first.conn == nil
+	first.mu.Unlock()
+	if !firstClosed {
+		t.Error("Register should close the client it replaces")
+	}
+
+	if second.endpoint != "second.local:7000" {
+		t.Errorf("replaced client endpoint = %q, want second.local:7000", second.endpoint)
+	}
+	if first == second {
+		t.Error("Register should install a new client instance when replacing an existing plugin ID")
+	}
+}
+
+func TestPluginClientPool_CloseClearsAllClients(t *testing.T) {
+	pool := NewPluginClientPool(nil)
+	if err := pool.Register("plugin-a", "a.local:7000"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := pool.Get("plugin-a"); err == nil {
+		t.Fatal("expected Get to fail for a plugin ID removed by Close")
+	}
+}
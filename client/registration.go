@@ -6,39 +6,102 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	pb "github.com/neurosimio/simulator-plugin-interface/proto/v1"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
-	DefaultAPIHost        = "localhost"
-	DefaultAPIPort        = "8080"
-	DefaultTimeout        = 30 * time.Second
-	RegisterPath          = "/api/v1/plugins/register"
-	UnregisterPathPattern = "/api/v1/plugins/%s"
-	HealthPath            = "/health"
+	DefaultAPIHost           = "localhost"
+	DefaultAPIPort           = "8080"
+	DefaultTimeout           = 30 * time.Second
+	DefaultHeartbeatInterval = 15 * time.Second
+	RegisterPath             = "/api/v1/plugins/register"
+	UnregisterPathPattern    = "/api/v1/plugins/%s"
+	HeartbeatPathPattern     = "/api/v1/plugins/%s/heartbeat"
+	HealthPath               = "/health"
 )
 
+// ErrPluginUnknown is returned by Heartbeat when the API doesn't recognize
+// the plugin ID, which happens when the API has restarted (and lost its
+// in-memory registry) since this plugin last registered.
+var ErrPluginUnknown = errors.New("plugin ID not recognized by API")
+
 // RegistrationConfig contains configuration for plugin registration
 type RegistrationConfig struct {
-	APIHost     string        `json:"apiHost"`
-	APIPort     string        `json:"apiPort"`
-	Timeout     time.Duration `json:"timeout"`
-	PluginID    string        `json:"pluginId"`
+	APIHost     string             `json:"apiHost"`
+	APIPort     string             `json:"apiPort"`
+	Timeout     time.Duration      `json:"timeout"`
+	PluginID    string             `json:"pluginId"`
 	Manifest    *pb.PluginManifest `json:"manifest"`
-	GRPCAddress string        `json:"grpcAddress"`
+	GRPCAddress string             `json:"grpcAddress"`
+
+	// TLS configures mTLS for the registration HTTP client. When nil, the
+	// client falls back to plaintext HTTP against APIHost/APIPort.
+	TLS *TLSConfig `json:"tls"`
+
+	// Pool, when set, receives this plugin's GRPCAddress under its
+	// assigned PluginID as soon as RegisterPlugin succeeds, so callers
+	// that hold the pool can immediately dial it.
+	Pool *PluginClientPool `json:"-"`
+
+	// HeartbeatInterval controls how often Run sends a heartbeat while
+	// blocking. Defaults to DefaultHeartbeatInterval.
+	HeartbeatInterval time.Duration `json:"heartbeatInterval"`
+
+	// Resolver, when set, discovers the API's endpoints dynamically
+	// instead of dialing the static APIHost/APIPort pair, so a plugin can
+	// register with e.g. "neurosim-api.service.consul" resolved via
+	// Consul or DNS SRV. RegistrationClient tries discovered endpoints in
+	// order on failure and caches whichever one is healthy.
+	Resolver Resolver `json:"-"`
 }
 
-// RegistrationClient handles plugin registration with the simulation API
+// RegistrationClient handles plugin registration with the simulation API.
+// It is safe for concurrent use by multiple goroutines - for example,
+// calling HealthCheck or RegisterPlugin from one goroutine while Run ticks
+// Heartbeat in the background - because the only state any method mutates
+// after construction (resolvedBaseURL and config.PluginID) is guarded by
+// mu. config's other fields (Manifest aside, which is only ever written by
+// RegisterPlugin under mu) are treated as fixed for the client's lifetime.
 type RegistrationClient struct {
 	config     *RegistrationConfig
 	httpClient *http.Client
-	baseURL    string
+	scheme     string
+
+	// baseURL is the static fallback endpoint built from
+	// APIHost/APIPort, used directly when config.Resolver is nil.
+	baseURL string
+
+	mu sync.Mutex
+
+	// resolvedBaseURL caches the last endpoint that successfully
+	// answered a request, so subsequent calls don't re-resolve and
+	// re-probe the whole endpoint list every time. Guarded by mu.
+	resolvedBaseURL string
+}
+
+// pluginID returns the plugin ID assigned by the most recent successful
+// RegisterPlugin call. Guarded by mu since Heartbeat and UnregisterPlugin
+// may read it concurrently with Run re-registering in the background.
+func (c *RegistrationClient) pluginID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.PluginID
+}
+
+// setPluginID records the plugin ID assigned by a successful
+// RegisterPlugin call. Guarded by mu for the same reason as pluginID.
+func (c *RegistrationClient) setPluginID(id string) {
+	c.mu.Lock()
+	c.config.PluginID = id
+	c.mu.Unlock()
 }
 
 // RegistrationRequest wraps the manifest for API registration
@@ -54,8 +117,11 @@ type RegistrationResponse struct {
 	Timestamp string `json:"timestamp"`
 }
 
-// NewRegistrationClient creates a new plugin registration client
-func NewRegistrationClient(config *RegistrationConfig) *RegistrationClient {
+// NewRegistrationClient creates a new plugin registration client. If
+// config.TLS is set, the client dials the API over HTTPS using the same
+// trust store (and, for mTLS, the same client certificate) that
+// NewPluginServiceClient uses for gRPC.
+func NewRegistrationClient(config *RegistrationConfig) (*RegistrationClient, error) {
 	if config.APIHost == "" {
 		config.APIHost = DefaultAPIHost
 	}
@@ -66,113 +132,205 @@ func NewRegistrationClient(config *RegistrationConfig) *RegistrationClient {
 		config.Timeout = DefaultTimeout
 	}
 
+	transport, err := httpTransport(config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure registration TLS: %w", err)
+	}
+
+	scheme := "http"
+	if transport != nil {
+		scheme = "https"
+	}
+
 	client := &RegistrationClient{
 		config: config,
 		httpClient: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: transport,
 		},
-		baseURL: fmt.Sprintf("http://%s:%s", config.APIHost, config.APIPort),
+		scheme:  scheme,
+		baseURL: fmt.Sprintf("%s://%s:%s", scheme, config.APIHost, config.APIPort),
 	}
 
-	return client
+	return client, nil
 }
 
-// RegisterPlugin registers the plugin with the simulation API
-func (c *RegistrationClient) RegisterPlugin() error {
-	if c.config.Manifest == nil {
-		return fmt.Errorf("plugin manifest is required for registration")
+// candidateBaseURLs returns the base URLs to try, in preference order. With
+// no Resolver configured this is just the static APIHost/APIPort pair; with
+// a Resolver it's every discovered endpoint, with whichever endpoint last
+// succeeded moved to the front. ctx bounds the resolver lookup itself, so a
+// hung DNS/Consul/Kubernetes query can't block the caller forever.
+func (c *RegistrationClient) candidateBaseURLs(ctx context.Context) ([]string, error) {
+	if c.config.Resolver == nil {
+		return []string{c.baseURL}, nil
 	}
 
-	// Update manifest with actual gRPC endpoint
-	if c.config.GRPCAddress != "" {
-		c.config.Manifest.GrpcEndpoint = c.config.GRPCAddress
+	endpoints, err := c.config.Resolver.Resolve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve API endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("resolver returned no API endpoints")
 	}
 
-	request := &RegistrationRequest{
-		Manifest: c.config.Manifest,
+	urls := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		urls[i] = fmt.Sprintf("%s://%s", c.scheme, ep.Address())
 	}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("failed to marshal registration request: %w", err)
+	c.mu.Lock()
+	resolved := c.resolvedBaseURL
+	c.mu.Unlock()
+
+	if resolved != "" {
+		for i, url := range urls {
+			if url == resolved {
+				urls[0], urls[i] = urls[i], urls[0]
+				break
+			}
+		}
 	}
 
-	registerURL := c.baseURL + RegisterPath
-	resp, err := c.httpClient.Post(registerURL, "application/json", bytes.NewBuffer(jsonData))
+	return urls, nil
+}
+
+// withEndpoint calls fn with each candidate base URL in turn, stopping at
+// the first one fn doesn't return an error for and caching it as
+// resolvedBaseURL. If every candidate fails, it returns the last error.
+func (c *RegistrationClient) withEndpoint(ctx context.Context, fn func(baseURL string) error) error {
+	urls, err := c.candidateBaseURLs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to send registration request: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("registration failed with status: %d", resp.StatusCode)
+	var lastErr error
+	for _, url := range urls {
+		if err := fn(url); err != nil {
+			lastErr = err
+			continue
+		}
+		c.mu.Lock()
+		c.resolvedBaseURL = url
+		c.mu.Unlock()
+		return nil
 	}
 
-	var regResp RegistrationResponse
-	if err := json.NewDecoder(resp.Body).Decode(&regResp); err != nil {
-		return fmt.Errorf("failed to decode registration response: %w", err)
+	return fmt.Errorf("all endpoints failed, last error: %w", lastErr)
+}
+
+// RegisterPlugin registers the plugin with the simulation API
+func (c *RegistrationClient) RegisterPlugin(ctx context.Context) error {
+	c.mu.Lock()
+	manifest := c.config.Manifest
+	if manifest == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("plugin manifest is required for registration")
+	}
+	grpcAddress := c.config.GRPCAddress
+	if grpcAddress != "" {
+		// Update manifest with actual gRPC endpoint
+		manifest.GrpcEndpoint = grpcAddress
 	}
 
-	if !regResp.Success {
-		return fmt.Errorf("registration failed: %s", regResp.Message)
+	request := &RegistrationRequest{
+		Manifest: manifest,
 	}
 
-	c.config.PluginID = regResp.PluginID
-	return nil
+	// Marshal while still holding mu so a concurrent RegisterPlugin can't
+	// write GrpcEndpoint while the manifest is being read.
+	jsonData, err := json.Marshal(request)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal registration request: %w", err)
+	}
+
+	return c.withEndpoint(ctx, func(baseURL string) error {
+		resp, err := c.httpClient.Post(baseURL+RegisterPath, "application/json", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to send registration request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("registration failed with status: %d", resp.StatusCode)
+		}
+
+		var regResp RegistrationResponse
+		if err := json.NewDecoder(resp.Body).Decode(&regResp); err != nil {
+			return fmt.Errorf("failed to decode registration response: %w", err)
+		}
+
+		if !regResp.Success {
+			return fmt.Errorf("registration failed: %s", regResp.Message)
+		}
+
+		c.setPluginID(regResp.PluginID)
+
+		if c.config.Pool != nil && grpcAddress != "" {
+			if err := c.config.Pool.Register(regResp.PluginID, grpcAddress); err != nil {
+				return fmt.Errorf("registered but failed to publish to client pool: %w", err)
+			}
+		}
+
+		return nil
+	})
 }
 
 // UnregisterPlugin removes the plugin from the simulation API
-func (c *RegistrationClient) UnregisterPlugin() error {
-	if c.config.PluginID == "" {
+func (c *RegistrationClient) UnregisterPlugin(ctx context.Context) error {
+	pluginID := c.pluginID()
+	if pluginID == "" {
 		return fmt.Errorf("plugin ID is required for unregistration")
 	}
 
-	unregisterURL := c.baseURL + fmt.Sprintf(UnregisterPathPattern, c.config.PluginID)
-	req, err := http.NewRequest("DELETE", unregisterURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create unregister request: %w", err)
-	}
+	return c.withEndpoint(ctx, func(baseURL string) error {
+		req, err := http.NewRequest("DELETE", baseURL+fmt.Sprintf(UnregisterPathPattern, pluginID), nil)
+		if err != nil {
+			return fmt.Errorf("failed to create unregister request: %w", err)
+		}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send unregister request: %w", err)
-	}
-	defer resp.Body.Close()
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send unregister request: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("unregistration failed with status: %d", resp.StatusCode)
-	}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("unregistration failed with status: %d", resp.StatusCode)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // HealthCheck performs a health check against the simulation API
-func (c *RegistrationClient) HealthCheck() error {
-	healthURL := c.baseURL + HealthPath
-	resp, err := c.httpClient.Get(healthURL)
-	if err != nil {
-		return fmt.Errorf("health check failed: %w", err)
-	}
-	defer resp.Body.Close()
+func (c *RegistrationClient) HealthCheck(ctx context.Context) error {
+	return c.withEndpoint(ctx, func(baseURL string) error {
+		resp, err := c.httpClient.Get(baseURL + HealthPath)
+		if err != nil {
+			return fmt.Errorf("health check failed: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API health check failed with status: %d", resp.StatusCode)
-	}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("API health check failed with status: %d", resp.StatusCode)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // RegisterWithRetries attempts to register the plugin with exponential backoff
-func (c *RegistrationClient) RegisterWithRetries(maxRetries int, baseDelay time.Duration) error {
+func (c *RegistrationClient) RegisterWithRetries(ctx context.Context, maxRetries int, baseDelay time.Duration) error {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// First check if API is healthy
-		if err := c.HealthCheck(); err != nil {
+		if err := c.HealthCheck(ctx); err != nil {
 			lastErr = fmt.Errorf("API health check failed: %w", err)
 		} else {
 			// API is healthy, try to register
-			if err := c.RegisterPlugin(); err != nil {
+			if err := c.RegisterPlugin(ctx); err != nil {
 				lastErr = fmt.Errorf("registration failed: %w", err)
 			} else {
 				return nil // Success
@@ -189,78 +347,87 @@ func (c *RegistrationClient) RegisterWithRetries(maxRetries int, baseDelay time.
 	return fmt.Errorf("all registration attempts failed, last error: %w", lastErr)
 }
 
-// PluginServiceClient creates a gRPC client for connecting to other plugins
-type PluginServiceClient struct {
-	conn   *grpc.ClientConn
-	client pb.PluginServiceClient
-}
+// Heartbeat tells the simulation API that this plugin is still alive,
+// updating the lastSeen timestamp the API uses to detect crashed plugins.
+// It returns ErrPluginUnknown if the API has restarted and no longer
+// recognizes the plugin ID, so the caller can re-register.
+func (c *RegistrationClient) Heartbeat(ctx context.Context) error {
+	pluginID := c.pluginID()
+	if pluginID == "" {
+		return fmt.Errorf("plugin ID is required for heartbeat")
+	}
 
-// NewPluginServiceClient creates a new gRPC client for plugin communication
-func NewPluginServiceClient(endpoint string) (*PluginServiceClient, error) {
-	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	urls, err := c.candidateBaseURLs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to plugin at %s: %w", endpoint, err)
+		return err
 	}
 
-	return &PluginServiceClient{
-		conn:   conn,
-		client: pb.NewPluginServiceClient(conn),
-	}, nil
-}
-
-// Close closes the gRPC connection
-func (c *PluginServiceClient) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urls[0]+fmt.Sprintf(HeartbeatPathPattern, pluginID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat request: %w", err)
 	}
-	return nil
-}
-
-// HealthCheck performs a health check on the connected plugin
-func (c *PluginServiceClient) HealthCheck(ctx context.Context, service string) (*pb.HealthCheckResponse, error) {
-	req := &pb.HealthCheckRequest{
-		Service: service,
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("heartbeat failed: %w", err)
 	}
-	return c.client.HealthCheck(ctx, req)
-}
+	defer resp.Body.Close()
 
-// GetManifest retrieves the plugin's manifest
-func (c *PluginServiceClient) GetManifest(ctx context.Context) (*pb.PluginManifest, error) {
-	req := &pb.GetManifestRequest{}
-	resp, err := c.client.GetManifest(ctx, req)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPluginUnknown
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("heartbeat failed with status: %d", resp.StatusCode)
 	}
-	return resp.Manifest, nil
+
+	return nil
 }
 
-// ProcessMessage sends a message to a component plugin for processing
-func (c *PluginServiceClient) ProcessMessage(ctx context.Context, instanceID string, message *pb.SimMessage) (*pb.ProcessMessageResponse, error) {
-	req := &pb.ProcessMessageRequest{
-		InstanceId: instanceID,
-		Message:    message,
+// Run registers the plugin, then blocks sending periodic heartbeats until
+// ctx is canceled or the process receives SIGINT/SIGTERM, at which point it
+// unregisters the plugin before returning. If the API reports that the
+// plugin ID is no longer recognized (e.g. after an API restart), Run
+// transparently re-registers and continues. This gives plugin authors a
+// single blocking call instead of hand-wiring register/heartbeat/unregister.
+func (c *RegistrationClient) Run(ctx context.Context) error {
+	runCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := c.RegisterPlugin(runCtx); err != nil {
+		return fmt.Errorf("failed to register plugin: %w", err)
 	}
-	return c.client.ProcessMessage(ctx, req)
-}
 
-// EncodeMessage encodes a message using a message ICD plugin
-func (c *PluginServiceClient) EncodeMessage(ctx context.Context, messageType, format string, payload map[string]interface{}) (*pb.EncodeMessageResponse, error) {
-	// Convert payload to protobuf Struct
-	// Note: This is a simplified implementation - you may want to use structpb.NewStruct
-	req := &pb.EncodeMessageRequest{
-		MessageType: messageType,
-		Format:      format,
-		// Payload: payloadStruct, // TODO: Convert map to structpb.Struct
+	interval := c.config.HeartbeatInterval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
 	}
-	return c.client.EncodeMessage(ctx, req)
-}
 
-// DecodeMessage decodes a message using a message ICD plugin
-func (c *PluginServiceClient) DecodeMessage(ctx context.Context, messageType, contentType string, encodedPayload []byte) (*pb.DecodeMessageResponse, error) {
-	req := &pb.DecodeMessageRequest{
-		MessageType:    messageType,
-		ContentType:    contentType,
-		EncodedPayload: encodedPayload,
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runCtx.Done():
+			// runCtx is already Done here (that's why we're in this case),
+			// and ctx may be too, so the final unregister gets its own
+			// bounded context rather than inheriting a dead one.
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+			err := c.UnregisterPlugin(shutdownCtx)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to unregister plugin during shutdown: %w", err)
+			}
+			return nil
+
+		case <-ticker.C:
+			if err := c.Heartbeat(runCtx); err != nil {
+				if errors.Is(err, ErrPluginUnknown) {
+					if regErr := c.RegisterPlugin(runCtx); regErr != nil {
+						return fmt.Errorf("failed to re-register after API lost this plugin: %w", regErr)
+					}
+				}
+				// A transient heartbeat failure is left to resolve itself
+				// on the next tick rather than aborting Run.
+			}
+		}
 	}
-	return c.client.DecodeMessage(ctx, req)
-}
\ No newline at end of file
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// multiResolver is a Resolver that always resolves to a fixed, ordered list
+// of endpoints, used to stand in for a real Consul/DNS lookup in tests.
+type multiResolver struct {
+	endpoints []Endpoint
+}
+
+func (r multiResolver) Resolve(ctx context.Context) ([]Endpoint, error) {
+	return r.endpoints, nil
+}
+
+func splitServerURL(t *testing.T, rawURL string) (host, port string) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parse URL %q: %v", rawURL, err)
+	}
+	host, port, err = net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatalf("split host:port from %q: %v", u.Host, err)
+	}
+	return host, port
+}
+
+func TestRegistrationClient_WithEndpointFailsOverAndCaches(t *testing.T) {
+	var healthHits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == HealthPath {
+			atomic.AddInt32(&healthHits, 1)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dead := Endpoint{Host: "127.0.0.1", Port: "1"} // nothing listens here
+	host, port := splitServerURL(t, server.URL)
+	good := Endpoint{Host: host, Port: port}
+
+	client, err := NewRegistrationClient(&RegistrationConfig{
+		Resolver: multiResolver{endpoints: []Endpoint{dead, good}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistrationClient: %v", err)
+	}
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	if got := atomic.LoadInt32(&healthHits); got != 1 {
+		t.Fatalf("health endpoint hit %d times, want 1", got)
+	}
+	wantURL := "http://" + good.Address()
+	if urls, err := client.candidateBaseURLs(context.Background()); err != nil || urls[0] != wantURL {
+		t.Fatalf("candidateBaseURLs = %v, %v; want %s first", urls, err, wantURL)
+	}
+
+	// The working endpoint is now cached as resolvedBaseURL and moved to
+	// the front of the candidate list, so a second call shouldn't need to
+	// fail against the dead endpoint again first.
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("second HealthCheck: %v", err)
+	}
+	if got := atomic.LoadInt32(&healthHits); got != 2 {
+		t.Fatalf("health endpoint hit %d times after second call, want 2", got)
+	}
+}
+
+func TestRegistrationClient_WithEndpointReturnsLastErrorWhenAllFail(t *testing.T) {
+	client, err := NewRegistrationClient(&RegistrationConfig{
+		Resolver: multiResolver{endpoints: []Endpoint{
+			{Host: "127.0.0.1", Port: "1"},
+			{Host: "127.0.0.1", Port: "2"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistrationClient: %v", err)
+	}
+
+	if err := client.HealthCheck(context.Background()); err == nil {
+		t.Fatal("expected HealthCheck to fail when every resolved endpoint is unreachable")
+	}
+}
+
+func TestRegistrationClient_HeartbeatRequiresPluginID(t *testing.T) {
+	client, err := NewRegistrationClient(&RegistrationConfig{})
+	if err != nil {
+		t.Fatalf("NewRegistrationClient: %v", err)
+	}
+	if err := client.Heartbeat(context.Background()); err == nil {
+		t.Fatal("expected Heartbeat to fail before any plugin ID has been assigned")
+	}
+}
+
+func TestRegistrationClient_UnregisterPluginRequiresPluginID(t *testing.T) {
+	client, err := NewRegistrationClient(&RegistrationConfig{})
+	if err != nil {
+		t.Fatalf("NewRegistrationClient: %v", err)
+	}
+	if err := client.UnregisterPlugin(context.Background()); err == nil {
+		t.Fatal("expected UnregisterPlugin to fail before any plugin ID has been assigned")
+	}
+}
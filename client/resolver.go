@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is a resolved host/port pair for either the simulation API or a
+// peer plugin's gRPC service.
+type Endpoint struct {
+	Host string
+	Port string
+}
+
+// Address returns the endpoint in "host:port" form, as expected by
+// grpc.NewClient and used to build HTTP base URLs.
+func (e Endpoint) Address() string {
+	return net.JoinHostPort(e.Host, e.Port)
+}
+
+// Resolver discovers the current set of endpoints behind a logical service
+// name. Implementations should return endpoints in preference order, with
+// the intent that callers try them in order and cache whichever one turns
+// out to be healthy.
+type Resolver interface {
+	Resolve(ctx context.Context) ([]Endpoint, error)
+}
+
+// StaticResolver resolves to a fixed, pre-configured set of endpoints. It
+// is not used internally: a RegistrationConfig or PluginClientConfig with
+// no Resolver dials its plain host:port directly.
+type StaticResolver struct {
+	Endpoints []Endpoint
+}
+
+// Resolve implements Resolver.
+func (r StaticResolver) Resolve(ctx context.Context) ([]Endpoint, error) {
+	return r.Endpoints, nil
+}
+
+// DNSSRVResolver discovers endpoints via a DNS SRV lookup, e.g.
+// "_neurosim._tcp.service.consul". Service and Proto are combined with
+// Name as per net.LookupSRV; leave Service and Proto empty to look up Name
+// directly as an already-formed SRV record name.
+type DNSSRVResolver struct {
+	Service string
+	Proto   string
+	Name    string
+}
+
+// Resolve implements Resolver.
+func (r *DNSSRVResolver) Resolve(ctx context.Context) ([]Endpoint, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, fmt.Errorf("SRV lookup for %s failed: %w", r.Name, err)
+	}
+
+	endpoints := make([]Endpoint, len(records))
+	for i, rec := range records {
+		endpoints[i] = Endpoint{
+			Host: strings.TrimSuffix(rec.Target, "."),
+			Port: strconv.Itoa(int(rec.Port)),
+		}
+	}
+	return endpoints, nil
+}
+
+// KubernetesResolver discovers endpoints via a headless Kubernetes
+// Service's DNS name, which resolves to one A/AAAA record per ready pod
+// rather than a single ClusterIP. Port is fixed since headless service DNS
+// doesn't carry port information the way SRV records do.
+type KubernetesResolver struct {
+	Name string
+	Port string
+}
+
+// Resolve implements Resolver.
+func (r *KubernetesResolver) Resolve(ctx context.Context) ([]Endpoint, error) {
+	hosts, err := net.DefaultResolver.LookupHost(ctx, r.Name)
+	if err != nil {
+		return nil, fmt.Errorf("headless service lookup for %s failed: %w", r.Name, err)
+	}
+
+	endpoints := make([]Endpoint, len(hosts))
+	for i, host := range hosts {
+		endpoints[i] = Endpoint{Host: host, Port: r.Port}
+	}
+	return endpoints, nil
+}
+
+// consulCatalogEntry mirrors the fields used from Consul's
+// /v1/catalog/service/{name} response.
+type consulCatalogEntry struct {
+	ServiceAddress string `json:"ServiceAddress"`
+	Address        string `json:"Address"`
+	ServicePort    int    `json:"ServicePort"`
+}
+
+// ConsulResolver discovers endpoints via Consul's catalog HTTP API.
+type ConsulResolver struct {
+	// ConsulAddr is the base URL of the Consul HTTP API, e.g.
+	// "http://127.0.0.1:8500".
+	ConsulAddr string
+
+	// ServiceName is the Consul service name to look up.
+	ServiceName string
+
+	// HTTPClient is used to query Consul. A nil value uses
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Resolve implements Resolver.
+func (r *ConsulResolver) Resolve(ctx context.Context) ([]Endpoint, error) {
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	url := fmt.Sprintf("%s/v1/catalog/service/%s", strings.TrimRight(r.ConsulAddr, "/"), r.ServiceName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Consul catalog request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Consul catalog lookup for %s failed: %w", r.ServiceName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Consul catalog lookup for %s failed with status: %d", r.ServiceName, resp.StatusCode)
+	}
+
+	var entries []consulCatalogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode Consul catalog response: %w", err)
+	}
+
+	endpoints := make([]Endpoint, len(entries))
+	for i, entry := range entries {
+		host := entry.ServiceAddress
+		if host == "" {
+			host = entry.Address
+		}
+		endpoints[i] = Endpoint{Host: host, Port: strconv.Itoa(entry.ServicePort)}
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryPolicy_BackoffDoublesAndCapsAtMaxDelay(t *testing.T) {
+	policy := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  2 * time.Second,
+	}
+
+	// backoff mixes in up to 50% jitter on top of a delay/2 floor, so each
+	// attempt's result should land in [delay/2, delay), where delay is the
+	// doubled-and-capped value for that attempt.
+	cases := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, 1600 * time.Millisecond},
+		{6, 2 * time.Second}, // would be 3.2s uncapped; MaxDelay caps it
+		{10, 2 * time.Second},
+	}
+
+	for _, tc := range cases {
+		got := policy.backoff(tc.attempt)
+		min := tc.delay / 2
+		max := tc.delay
+		if got < min || got >= max {
+			t.Errorf("backoff(%d) = %v, want in [%v, %v)", tc.attempt, got, min, max)
+		}
+	}
+}
+
+func TestRetryPolicy_BackoffZeroMaxDelayNeverCaps(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond}
+
+	delay := 100 * time.Millisecond * (1 << uint(9))
+	got := policy.backoff(10)
+	if got < delay/2 || got >= delay {
+		t.Errorf("backoff(10) = %v, want in [%v, %v)", got, delay/2, delay)
+	}
+}
+
+func TestRetryPolicy_StreamTimeoutScalesWithPayloadSize(t *testing.T) {
+	policy := RetryPolicy{StreamMinTimeout: 30 * time.Second, StreamThroughput: 1 << 20}
+
+	if got := policy.streamTimeout(0); got != 30*time.Second {
+		t.Errorf("streamTimeout(0) = %v, want the 30s floor", got)
+	}
+
+	got := policy.streamTimeout(100 << 20) // 100MiB at 1MiB/s
+	if got < 99*time.Second || got > 101*time.Second {
+		t.Errorf("streamTimeout(100MiB) = %v, want ~100s", got)
+	}
+}
+
+func TestRetryPolicy_StreamTimeoutDefaultsWhenUnset(t *testing.T) {
+	var policy RetryPolicy
+	if got := policy.streamTimeout(0); got != 30*time.Second {
+		t.Errorf("streamTimeout(0) with zero-value policy = %v, want the 30s default floor", got)
+	}
+}
+
+func TestRetryPolicy_OrDefaultFillsZeroValue(t *testing.T) {
+	policy := RetryPolicy{}.orDefault()
+	if policy.MaxAttempts != DefaultRetryPolicy().MaxAttempts {
+		t.Errorf("orDefault() on zero-value policy = %+v, want DefaultRetryPolicy()", policy)
+	}
+}
+
+func TestRetryPolicy_OrDefaultPreservesExplicitSettings(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 1}.orDefault()
+	if policy.MaxAttempts != 1 {
+		t.Errorf("orDefault() with MaxAttempts set = %+v, want MaxAttempts preserved at 1", policy)
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{status.Error(codes.Unavailable, "down"), true},
+		{status.Error(codes.DeadlineExceeded, "slow"), true},
+		{status.Error(codes.ResourceExhausted, "overloaded"), true},
+		{status.Error(codes.Aborted, "conflict"), true},
+		{status.Error(codes.InvalidArgument, "bad input"), false},
+		{status.Error(codes.NotFound, "missing"), false},
+		{status.Error(codes.PermissionDenied, "denied"), false},
+		{errors.New("not a status error"), false},
+		{nil, false},
+	}
+
+	for _, tc := range cases {
+		if got := isRetryableStatus(tc.err); got != tc.want {
+			t.Errorf("isRetryableStatus(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
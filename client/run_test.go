@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/neurosimio/simulator-plugin-interface/proto/v1"
+)
+
+func TestRegistrationClient_RunReRegistersOnErrPluginUnknownAndUnregistersOnShutdown(t *testing.T) {
+	var (
+		mu              sync.Mutex
+		registerCalls   int
+		heartbeatCalls  int
+		unregisterCalls int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == RegisterPath:
+			mu.Lock()
+			registerCalls++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(RegistrationResponse{Success: true, PluginID: "p1"})
+
+		case r.Method == http.MethodPost && r.URL.Path == fmt.Sprintf(HeartbeatPathPattern, "p1"):
+			mu.Lock()
+			heartbeatCalls++
+			firstHeartbeat := heartbeatCalls == 1
+			mu.Unlock()
+			if firstHeartbeat {
+				// Simulate the API having restarted and lost this plugin,
+				// which Run should respond to by re-registering.
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+
+		case r.Method == http.MethodDelete && r.URL.Path == fmt.Sprintf(UnregisterPathPattern, "p1"):
+			mu.Lock()
+			unregisterCalls++
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host, port := splitServerURL(t, server.URL)
+	client, err := NewRegistrationClient(&RegistrationConfig{
+		APIHost:           host,
+		APIPort:           port,
+		Manifest:          &pb.PluginManifest{},
+		HeartbeatInterval: 20 * time.Millisecond,
+		Timeout:           time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRegistrationClient: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	if err := client.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if registerCalls < 2 {
+		t.Errorf("registerCalls = %d, want at least 2 (initial register plus re-register after ErrPluginUnknown)", registerCalls)
+	}
+	if heartbeatCalls < 1 {
+		t.Errorf("heartbeatCalls = %d, want at least 1", heartbeatCalls)
+	}
+	if unregisterCalls != 1 {
+		t.Errorf("unregisterCalls = %d, want exactly 1 on shutdown", unregisterCalls)
+	}
+}
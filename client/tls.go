@@ -0,0 +1,163 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig describes the transport credentials used when dialing the
+// simulation API or a peer plugin. It covers the common mTLS case (CA
+// bundle plus a client certificate/key) as well as SPIFFE-style workload
+// identity, where the certificate and trust bundle are rotated on disk by
+// an agent (e.g. SPIRE) rather than supplied once at startup.
+type TLSConfig struct {
+	// Insecure disables transport security entirely. This should only be
+	// used for local development against a plugin running on localhost.
+	Insecure bool `json:"insecure"`
+
+	// CAFile is a PEM-encoded bundle of trusted root certificates. If
+	// empty, the host's system trust store is used.
+	CAFile string `json:"caFile"`
+
+	// CertFile and KeyFile are a PEM-encoded client certificate/key pair
+	// presented during mTLS handshakes. Both must be set together.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// ServerNameOverride overrides the server name used for SNI and
+	// certificate verification, useful when dialing by IP or through a
+	// load balancer that doesn't match the certificate's SAN list.
+	ServerNameOverride string `json:"serverNameOverride"`
+
+	// SPIFFETrustDomain, when set, indicates that CAFile/CertFile/KeyFile
+	// are SVIDs managed by a SPIFFE workload API agent and that peer
+	// identities should be verified against this trust domain instead of
+	// a traditional hostname: clientTLSConfig disables Go's hostname-based
+	// verification and instead requires the peer's leaf certificate to
+	// carry a spiffe://<trust-domain>/... URI SAN matching this value.
+	SPIFFETrustDomain string `json:"spiffeTrustDomain"`
+}
+
+// clientTLSConfig builds a *tls.Config from the TLS material referenced by
+// cfg. A nil cfg or one with Insecure set returns (nil, nil), signaling to
+// callers that they should fall back to a plaintext transport.
+func clientTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	if cfg == nil || cfg.Insecure {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		ServerName: cfg.ServerNameOverride,
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", cfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("both certFile and keyFile must be set for mTLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.SPIFFETrustDomain != "" {
+		// SVIDs are rotated by the workload agent and typically don't
+		// carry a DNS SAN stable enough to verify a hostname against, so
+		// trust-domain membership replaces hostname verification here.
+		// Go's handshake-time verification only knows how to check a
+		// hostname, so it's disabled in favor of doing both chain and
+		// trust-domain verification ourselves in VerifyPeerCertificate.
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyPeerCertificate = spiffePeerVerifier(cfg.SPIFFETrustDomain, tlsCfg.RootCAs)
+	}
+
+	return tlsCfg, nil
+}
+
+// spiffePeerVerifier returns a tls.Config.VerifyPeerCertificate callback
+// that performs the X.509 chain verification InsecureSkipVerify otherwise
+// skips, then requires the peer's leaf certificate to carry a
+// spiffe://<trust-domain>/... URI SAN matching trustDomain.
+func spiffePeerVerifier(trustDomain string, roots *x509.CertPool) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("no peer certificate presented")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse peer certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		leaf := certs[0]
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return fmt.Errorf("failed to verify peer certificate chain: %w", err)
+		}
+
+		for _, uri := range leaf.URIs {
+			if uri.Scheme == "spiffe" && uri.Host == trustDomain {
+				return nil
+			}
+		}
+		return fmt.Errorf("peer SPIFFE ID does not belong to trust domain %q", trustDomain)
+	}
+}
+
+// transportCredentials builds the gRPC transport credentials described by
+// cfg, falling back to plaintext when cfg is nil or has Insecure set.
+func transportCredentials(cfg *TLSConfig) (credentials.TransportCredentials, error) {
+	tlsCfg, err := clientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// httpTransport builds an *http.Transport for the registration client. A
+// nil cfg returns nil, signaling the caller to use http.DefaultTransport
+// over plaintext HTTP.
+func httpTransport(cfg *TLSConfig) (*http.Transport, error) {
+	tlsCfg, err := clientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		return nil, nil
+	}
+	return &http.Transport{TLSClientConfig: tlsCfg}, nil
+}
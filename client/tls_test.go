@@ -0,0 +1,186 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// genCert creates a PEM-encoded certificate/key pair signed by signer (or
+// self-signed if signer is nil), optionally carrying a spiffe:// URI SAN,
+// and returns the cert and key file paths written under dir.
+func genCert(t *testing.T, dir, name string, signer *x509.Certificate, signerKey *ecdsa.PrivateKey, spiffeID string) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  signer == nil,
+	}
+	if spiffeID != "" {
+		uri, err := url.Parse(spiffeID)
+		if err != nil {
+			t.Fatalf("parse spiffe ID: %v", err)
+		}
+		template.URIs = []*url.URL{uri}
+	}
+
+	parent, parentKey := template, key
+	if signer != nil {
+		parent, parentKey = signer, signerKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse created certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "EC PRIVATE KEY", keyDER)
+
+	return cert, key, certPath, keyPath
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := pem.Encode(f, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		t.Fatalf("encode %s: %v", path, err)
+	}
+}
+
+func TestClientTLSConfig_NilOrInsecureReturnsNil(t *testing.T) {
+	for _, cfg := range []*TLSConfig{nil, {Insecure: true}} {
+		tlsCfg, err := clientTLSConfig(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tlsCfg != nil {
+			t.Fatalf("expected nil *tls.Config, got %+v", tlsCfg)
+		}
+	}
+}
+
+func TestClientTLSConfig_LoadsCAAndClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, caPath, _ := genCert(t, dir, "ca", nil, nil, "")
+	_, _, certPath, keyPath := genCert(t, dir, "client", ca, caKey, "")
+
+	tlsCfg, err := clientTLSConfig(&TLSConfig{CAFile: caPath, CertFile: certPath, KeyFile: keyPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsCfg.RootCAs == nil {
+		t.Fatal("expected RootCAs to be populated from CAFile")
+	}
+	if len(tlsCfg.Certificates) != 1 {
+		t.Fatalf("expected one client certificate, got %d", len(tlsCfg.Certificates))
+	}
+}
+
+func TestClientTLSConfig_MissingCAFileErrors(t *testing.T) {
+	if _, err := clientTLSConfig(&TLSConfig{CAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+}
+
+func TestClientTLSConfig_CertWithoutKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, _, _ := genCert(t, dir, "ca", nil, nil, "")
+	_, _, certPath, _ := genCert(t, dir, "client", ca, caKey, "")
+
+	if _, err := clientTLSConfig(&TLSConfig{CertFile: certPath}); err == nil {
+		t.Fatal("expected an error when keyFile is omitted")
+	}
+}
+
+func TestSPIFFEPeerVerifier_AcceptsMatchingTrustDomain(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, _, _ := genCert(t, dir, "ca", nil, nil, "")
+	leaf, _, _, _ := genCert(t, dir, "workload", ca, caKey, "spiffe://neurosim.internal/ns/default/sa/plugin")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	verify := spiffePeerVerifier("neurosim.internal", roots)
+	if err := verify([][]byte{leaf.Raw}, nil); err != nil {
+		t.Fatalf("expected matching trust domain to verify, got: %v", err)
+	}
+}
+
+func TestSPIFFEPeerVerifier_RejectsWrongTrustDomain(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, _, _ := genCert(t, dir, "ca", nil, nil, "")
+	leaf, _, _, _ := genCert(t, dir, "workload", ca, caKey, "spiffe://other.internal/ns/default/sa/plugin")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	verify := spiffePeerVerifier("neurosim.internal", roots)
+	if err := verify([][]byte{leaf.Raw}, nil); err == nil {
+		t.Fatal("expected a trust domain mismatch to be rejected")
+	}
+}
+
+func TestSPIFFEPeerVerifier_RejectsUntrustedSigner(t *testing.T) {
+	dir := t.TempDir()
+	untrustedCA, untrustedKey, _, _ := genCert(t, dir, "untrusted-ca", nil, nil, "")
+	leaf, _, _, _ := genCert(t, dir, "workload", untrustedCA, untrustedKey, "spiffe://neurosim.internal/ns/default/sa/plugin")
+
+	roots := x509.NewCertPool() // does not include untrustedCA
+
+	verify := spiffePeerVerifier("neurosim.internal", roots)
+	if err := verify([][]byte{leaf.Raw}, nil); err == nil {
+		t.Fatal("expected a certificate from an untrusted signer to be rejected")
+	}
+}
+
+func TestClientTLSConfig_SPIFFEDisablesHostnameVerification(t *testing.T) {
+	dir := t.TempDir()
+	_, _, caPath, _ := genCert(t, dir, "ca", nil, nil, "")
+
+	tlsCfg, err := clientTLSConfig(&TLSConfig{CAFile: caPath, SPIFFETrustDomain: "neurosim.internal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tlsCfg.InsecureSkipVerify {
+		t.Fatal("expected InsecureSkipVerify to be set so VerifyPeerCertificate can take over")
+	}
+	if tlsCfg.VerifyPeerCertificate == nil {
+		t.Fatal("expected VerifyPeerCertificate to be set for trust-domain verification")
+	}
+}